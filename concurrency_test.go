@@ -0,0 +1,61 @@
+package simplelog
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentWritesAreRaceFree exercises Printf, SetOutput and the
+// package-level SetFormatter concurrently. It doesn't assert much
+// about the output itself - its job is to give `go test -race` something to
+// catch if any of these ever stop being properly synchronized.
+func TestConcurrentWritesAreRaceFree(t *testing.T) {
+	origDestination := Debug.destination
+	origFormatter := Debug.formatter
+	defer func() {
+		Debug.SetOutput(origDestination)
+		Debug.SetFormatter(origFormatter)
+		SetFormatter(TextFormatter{})
+	}()
+
+	var buf syncBuffer
+	Debug.SetOutput(&buf)
+	SetThreshold(LevelDebug)
+	defer SetThreshold(LevelError)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			Debug.Printf("hello %d", i)
+		}(i)
+		go func() {
+			defer wg.Done()
+			Debug.SetOutput(&buf)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				SetFormatter(TextFormatter{})
+			} else {
+				SetFormatter(LogfmtFormatter{})
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// syncBuffer wraps a bytes.Buffer with a mutex so tests that write to it
+// from multiple goroutines don't themselves race.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
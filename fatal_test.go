@@ -0,0 +1,90 @@
+package simplelog
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestFatalCallsExitFunc verifies that logging at Fatal invokes the
+// overridable ExitFunc instead of terminating the test process.
+func TestFatalCallsExitFunc(t *testing.T) {
+	origExit := ExitFunc
+	origDestination := Fatal.destination
+	defer func() {
+		ExitFunc = origExit
+		Fatal.SetOutput(origDestination)
+	}()
+
+	var buf bytes.Buffer
+	Fatal.SetOutput(&buf)
+
+	var exitCode int
+	exited := false
+	ExitFunc = func(code int) { exited = true; exitCode = code }
+
+	Fatal.Printf("disk on fire")
+
+	if !exited {
+		t.Fatal("Fatal.Printf did not call ExitFunc")
+	}
+	if exitCode != 1 {
+		t.Errorf("ExitFunc called with code %d, want 1", exitCode)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("disk on fire")) {
+		t.Errorf("Fatal.Printf output = %q, want it to contain the message", buf.String())
+	}
+}
+
+// TestPrintfnDoesNotExit verifies the no-exit Fatal variants never call
+// ExitFunc, even though they log at Fatal severity.
+func TestPrintfnDoesNotExit(t *testing.T) {
+	origExit := ExitFunc
+	origDestination := Fatal.destination
+	defer func() {
+		ExitFunc = origExit
+		Fatal.SetOutput(origDestination)
+	}()
+
+	var buf bytes.Buffer
+	Fatal.SetOutput(&buf)
+	ExitFunc = func(int) { t.Fatal("ExitFunc should not be called by Printfn/Printlnn") }
+
+	Fatal.Printfn("about to clean up: %d", 42)
+	Fatal.Printlnn("cleaning up now")
+
+	if !bytes.Contains(buf.Bytes(), []byte("about to clean up: 42")) {
+		t.Errorf("Fatal.Printfn output = %q, want it to contain the message", buf.String())
+	}
+}
+
+// TestExitFuncCanCallBackIntoSyncWithoutDeadlock guards against the
+// regression where ExitFunc was invoked while still holding l.mu: an
+// ExitFunc that flushes/syncs the same LogLevel it was triggered from used
+// to hang forever.
+func TestExitFuncCanCallBackIntoSyncWithoutDeadlock(t *testing.T) {
+	origExit := ExitFunc
+	origDestination := Fatal.destination
+	defer func() {
+		ExitFunc = origExit
+		Fatal.SetOutput(origDestination)
+	}()
+
+	var buf bytes.Buffer
+	Fatal.SetOutput(&buf)
+
+	called := false
+	ExitFunc = func(int) {
+		if err := Fatal.Sync(); err != nil {
+			t.Errorf("Fatal.Sync() from within ExitFunc returned error: %v", err)
+		}
+		called = true
+	}
+
+	// If write() still held l.mu while calling ExitFunc, this call would
+	// hang forever instead of returning.
+	Fatal.Printf("shutting down")
+
+	if !called {
+		t.Fatal("ExitFunc was not called")
+	}
+}
@@ -0,0 +1,53 @@
+package simplelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2026, time.July, 26, 3, 4, 5, 0, time.UTC)
+
+func TestTextFormatterFormat(t *testing.T) {
+	e := Entry{Time: testTime, Level: LevelInfo, Message: "user logged in", Fields: map[string]interface{}{"user": "alice"}}
+	got := string(TextFormatter{}.Format(e))
+	want := "INFO: 2026/07/26 03:04:05 user logged in user=alice\n"
+	if got != want {
+		t.Errorf("TextFormatter{}.Format(%+v) = %q, want %q", e, got, want)
+	}
+}
+
+func TestTextFormatterNoFields(t *testing.T) {
+	e := Entry{Time: testTime, Level: LevelDebug, Message: "starting up"}
+	got := string(TextFormatter{}.Format(e))
+	want := "DEBUG: 2026/07/26 03:04:05 starting up\n"
+	if got != want {
+		t.Errorf("TextFormatter{}.Format(%+v) = %q, want %q", e, got, want)
+	}
+}
+
+func TestLogfmtFormatterFormat(t *testing.T) {
+	e := Entry{Time: testTime, Level: LevelWarning, Message: "disk low", Fields: map[string]interface{}{"free_mb": 42}}
+	got := string(LogfmtFormatter{}.Format(e))
+	want := "ts=" + testTime.Format(time.RFC3339) + " level=warning msg=\"disk low\" free_mb=42\n"
+	if got != want {
+		t.Errorf("LogfmtFormatter{}.Format(%+v) = %q, want %q", e, got, want)
+	}
+}
+
+func TestLogfmtFormatterQuotesValuesWithSpaces(t *testing.T) {
+	e := Entry{Time: testTime, Level: LevelError, Message: "failed", Fields: map[string]interface{}{"reason": "not found"}}
+	got := string(LogfmtFormatter{}.Format(e))
+	if !strings.Contains(got, `reason="not found"`) {
+		t.Errorf("LogfmtFormatter{}.Format(%+v) = %q, want it to quote the value with spaces", e, got)
+	}
+}
+
+func TestJSONFormatterFormat(t *testing.T) {
+	e := Entry{Time: testTime, Level: LevelError, Message: "boom", Fields: map[string]interface{}{"code": float64(500)}}
+	got := string(JSONFormatter{}.Format(e))
+	want := `{"code":500,"level":"error","msg":"boom","ts":"` + testTime.Format(time.RFC3339) + "\"}\n"
+	if got != want {
+		t.Errorf("JSONFormatter{}.Format(%+v) = %q, want %q", e, got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package simplelog
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{"DEBUG", LevelDebug, false},
+		{"info", LevelInfo, false},
+		{"warn", LevelWarning, false},
+		{"warning", LevelWarning, false},
+		{"Error", LevelError, false},
+		{"fatal", LevelFatal, false},
+		{"nonsense", 0, true},
+	}
+
+	for _, c := range cases {
+		got, err := ParseLevel(c.in)
+		if c.wantErr {
+			if err != ErrInvalidLevel {
+				t.Errorf("ParseLevel(%q) error = %v, want ErrInvalidLevel", c.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseLevel(%q) unexpected error: %v", c.in, err)
+		}
+		if got != c.want {
+			t.Errorf("ParseLevel(%q) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestLevelName(t *testing.T) {
+	for level, want := range map[int]string{
+		LevelDebug:   "DEBUG",
+		LevelInfo:    "INFO",
+		LevelWarning: "WARNING",
+		LevelError:   "ERROR",
+		LevelFatal:   "FATAL",
+		99:           "UNKNOWN",
+	} {
+		if got := LevelName(level); got != want {
+			t.Errorf("LevelName(%d) = %q, want %q", level, got, want)
+		}
+	}
+}
+
+func TestSetThresholdFromString(t *testing.T) {
+	defer SetThreshold(LevelError)
+
+	if err := SetThresholdFromString("debug"); err != nil {
+		t.Fatalf("SetThresholdFromString(\"debug\") returned error: %v", err)
+	}
+	if LogThreshold() != LevelDebug {
+		t.Errorf("LogThreshold() = %d, want %d", LogThreshold(), LevelDebug)
+	}
+
+	if err := SetThresholdFromString("nonsense"); err != ErrInvalidLevel {
+		t.Errorf("SetThresholdFromString(\"nonsense\") error = %v, want ErrInvalidLevel", err)
+	}
+}
+
+func TestInitFromEnv(t *testing.T) {
+	defer SetThreshold(LevelError)
+	const envVar = "SIMPLELOG_TEST_LEVEL"
+
+	os.Unsetenv(envVar)
+	if err := InitFromEnv(envVar); err != nil {
+		t.Fatalf("InitFromEnv(%q) with unset env returned error: %v", envVar, err)
+	}
+	if LogThreshold() != LevelError {
+		t.Errorf("InitFromEnv with unset env changed threshold to %d, want unchanged %d", LogThreshold(), LevelError)
+	}
+
+	os.Setenv(envVar, "warning")
+	defer os.Unsetenv(envVar)
+	if err := InitFromEnv(envVar); err != nil {
+		t.Fatalf("InitFromEnv(%q) returned error: %v", envVar, err)
+	}
+	if LogThreshold() != LevelWarning {
+		t.Errorf("LogThreshold() = %d, want %d", LogThreshold(), LevelWarning)
+	}
+}
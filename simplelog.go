@@ -2,10 +2,17 @@
 package simplelog
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
-	"log"
 	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Constants for the different log levels supported by the library
@@ -17,34 +24,226 @@ const (
 	LevelFatal              // Threshold for Fatal log level (will crash the program when used)
 )
 
+// Entry represents a single log event, either produced by the plain
+// Printf/Println API (in which case Fields will be empty) or by the
+// structured Log/*w API.
+type Entry struct {
+	Time    time.Time
+	Level   int
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Formatter turns an Entry into the bytes that get written to a LogLevel's
+// destination. Implementations are responsible for adding their own
+// trailing newline.
+type Formatter interface {
+	Format(e Entry) []byte
+}
+
+// levelName returns the canonical, upper-case name for a log level.
+func levelName(level int) string {
+	switch level {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarning:
+		return "WARNING"
+	case LevelError:
+		return "ERROR"
+	case LevelFatal:
+		return "FATAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// sortedFieldKeys returns the keys of fields in sorted order, so formatted
+// output is deterministic.
+func sortedFieldKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TextFormatter renders entries as human-readable lines, matching the
+// library's original "LEVEL: <timestamp> message" style (as produced by
+// log.New(dest, "LEVEL: ", log.LstdFlags)), with any attribute pairs
+// appended as "key=value".
+type TextFormatter struct{}
+
+// Format implements Formatter.
+func (TextFormatter) Format(e Entry) []byte {
+	var b bytes.Buffer
+	b.WriteString(levelName(e.Level))
+	b.WriteString(": ")
+	b.WriteString(e.Time.Format("2006/01/02 15:04:05"))
+	b.WriteString(" ")
+	b.WriteString(e.Message)
+	for _, k := range sortedFieldKeys(e.Fields) {
+		fmt.Fprintf(&b, " %s=%v", k, e.Fields[k])
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
+// LogfmtFormatter renders entries as logfmt lines (ts=... level=... msg="..." key=value),
+// suitable for tools like heka or logfmt-aware aggregators.
+type LogfmtFormatter struct{}
+
+// Format implements Formatter.
+func (LogfmtFormatter) Format(e Entry) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "ts=%s level=%s msg=%s", e.Time.Format(time.RFC3339), strings.ToLower(levelName(e.Level)), logfmtValue(e.Message))
+	for _, k := range sortedFieldKeys(e.Fields) {
+		fmt.Fprintf(&b, " %s=%s", k, logfmtValue(e.Fields[k]))
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
+// logfmtValue formats a value for use on the right-hand side of a logfmt
+// key=value pair, quoting it if it contains spaces or quotes.
+func logfmtValue(v interface{}) string {
+	s := fmt.Sprintf("%v", v)
+	if strings.ContainsAny(s, " \"=") {
+		return strconvQuote(s)
+	}
+	return s
+}
+
+// strconvQuote quotes a string the way logfmt expects, reusing Go's
+// double-quoted string syntax.
+func strconvQuote(s string) string {
+	return fmt.Sprintf("%q", s)
+}
+
+// JSONFormatter renders entries as one JSON object per line, with "ts",
+// "level" and "msg" keys plus any attribute pairs merged in at the top level.
+type JSONFormatter struct{}
+
+// Format implements Formatter.
+func (JSONFormatter) Format(e Entry) []byte {
+	out := make(map[string]interface{}, len(e.Fields)+3)
+	for k, v := range e.Fields {
+		out[k] = v
+	}
+	out["ts"] = e.Time.Format(time.RFC3339)
+	out["level"] = strings.ToLower(levelName(e.Level))
+	out["msg"] = e.Message
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		// Fall back to a best-effort text line rather than dropping the entry.
+		return TextFormatter{}.Format(e)
+	}
+	return append(b, '\n')
+}
+
+// defaultFormatter is used by any LogLevel that doesn't have its own
+// formatter set. defaultFormatterMu guards it, since it's read from write()
+// and written from SetFormatter concurrently with logging.
+var (
+	defaultFormatterMu sync.RWMutex
+	defaultFormatter   Formatter = TextFormatter{}
+)
+
+// SetFormatter sets the formatter used by all LogLevels that don't have a
+// formatter of their own set via (*LogLevel).SetFormatter.
+func SetFormatter(f Formatter) {
+	defaultFormatterMu.Lock()
+	defer defaultFormatterMu.Unlock()
+	defaultFormatter = f
+}
+
+// getDefaultFormatter returns the current default formatter set via
+// SetFormatter.
+func getDefaultFormatter() Formatter {
+	defaultFormatterMu.RLock()
+	defer defaultFormatterMu.RUnlock()
+	return defaultFormatter
+}
+
+// Backend is an alternative destination for a LogLevel's messages, used
+// instead of the plain io.Writer destination. It receives the raw message
+// (any attribute pairs rendered as trailing "key=value" text, but without a
+// timestamp or level prefix, since most backends - syslog included - add
+// their own) along with the level it was logged at, so it can dispatch to
+// e.g. the matching syslog severity. See simplelog/syslog.
+type Backend interface {
+	Write(level int, msg string) error
+}
+
+// renderForBackend renders msg and its attribute pairs for a Backend,
+// deliberately leaving out the timestamp/level prefix a Formatter would add
+// for a plain io.Writer destination, since backends typically supply their
+// own.
+func renderForBackend(msg string, fields map[string]interface{}) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	var b bytes.Buffer
+	b.WriteString(msg)
+	for _, k := range sortedFieldKeys(fields) {
+		fmt.Fprintf(&b, " %s=%s", k, logfmtValue(fields[k]))
+	}
+	return b.String()
+}
+
 // LogLevel represents a logger object for a given log level.
 type LogLevel struct {
-	logger      *log.Logger
-	prefix      string
+	mu          sync.Mutex
 	level       int
 	destination io.Writer
+	formatter   Formatter
+	backend     Backend
 }
 
 // Logger objects that will be used to perform the actual logging.
 // Each of them represents a different logging level and can be pointed to a different backend (file, stdout, etc...)
 var (
-	Fatal   = &LogLevel{prefix: "FATAL: ", level: LevelFatal, destination: os.Stderr}
-	Error   = &LogLevel{prefix: "ERROR: ", level: LevelError, destination: os.Stderr}
-	Warning = &LogLevel{prefix: "WARNING: ", level: LevelWarning, destination: os.Stderr}
-	Info    = &LogLevel{prefix: "INFO: ", level: LevelInfo, destination: os.Stdout}
-	Debug   = &LogLevel{prefix: "DEBUG: ", level: LevelDebug, destination: os.Stdout}
+	Fatal   = &LogLevel{level: LevelFatal, destination: os.Stderr}
+	Error   = &LogLevel{level: LevelError, destination: os.Stderr}
+	Warning = &LogLevel{level: LevelWarning, destination: os.Stderr}
+	Info    = &LogLevel{level: LevelInfo, destination: os.Stdout}
+	Debug   = &LogLevel{level: LevelDebug, destination: os.Stdout}
 )
-var logThreshold = LevelError
+// logThreshold is stored as int32 and accessed via atomic so SetThreshold
+// (and friends) are safe to call concurrently with logging.
+var logThreshold int32 = int32(LevelError)
+
+// getLogThreshold returns the current logging threshold.
+func getLogThreshold() int {
+	return int(atomic.LoadInt32(&logThreshold))
+}
+
+// allLevels lists every LogLevel, in the same order they're declared above.
+var allLevels = []*LogLevel{Fatal, Error, Warning, Info, Debug}
 
 // Common errors that can be returned
 var (
 	ErrInvalidThreshold = errors.New("Invalid Threshold. Need one between LevelDebug and LevelFatal") // When an invalid threshold has been defined
 )
 
-func init() {
-	var levels = []*LogLevel{Fatal, Error, Warning, Info, Debug}
-	for _, level := range levels {
-		level.logger = log.New(level.destination, level.prefix, log.LstdFlags)
+// logLevelFor returns the LogLevel object backing the given level constant.
+func logLevelFor(level int) (*LogLevel, error) {
+	switch level {
+	case LevelDebug:
+		return Debug, nil
+	case LevelInfo:
+		return Info, nil
+	case LevelWarning:
+		return Warning, nil
+	case LevelError:
+		return Error, nil
+	case LevelFatal:
+		return Fatal, nil
+	default:
+		return nil, ErrInvalidThreshold
 	}
 }
 
@@ -54,40 +253,273 @@ func SetThreshold(t int) error {
 	if t < LevelDebug || t > LevelFatal {
 		return ErrInvalidThreshold
 	}
-	logThreshold = t
+	atomic.StoreInt32(&logThreshold, int32(t))
 	return nil
 }
 
+// ErrInvalidLevel is returned by ParseLevel when given a string that doesn't
+// match any known level name.
+var ErrInvalidLevel = errors.New("invalid log level")
+
+// LevelName returns the canonical, upper-case name for a log level (e.g.
+// "DEBUG" for LevelDebug), or "UNKNOWN" if level isn't a recognized LevelX
+// constant.
+func LevelName(level int) string {
+	return levelName(level)
+}
+
+// ParseLevel parses a case-insensitive level name ("debug", "info",
+// "warn"/"warning", "error" or "fatal") into its LevelX constant. Returns
+// ErrInvalidLevel if s doesn't match any known name.
+func ParseLevel(s string) (int, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarning, nil
+	case "error":
+		return LevelError, nil
+	case "fatal":
+		return LevelFatal, nil
+	default:
+		return 0, ErrInvalidLevel
+	}
+}
+
+// SetThresholdFromString parses s with ParseLevel and sets it as the
+// logging threshold.
+func SetThresholdFromString(s string) error {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	return SetThreshold(level)
+}
+
+// InitFromEnv reads envVar (e.g. "LOG_LEVEL") and, if it's set, parses it
+// with ParseLevel and applies it as the logging threshold. It's a no-op if
+// envVar isn't set.
+func InitFromEnv(envVar string) error {
+	s, ok := os.LookupEnv(envVar)
+	if !ok {
+		return nil
+	}
+	return SetThresholdFromString(s)
+}
+
 // IsDebug will return true if logging threshold is currently set at Debug level
 func IsDebug() bool {
-	return logThreshold == LevelDebug
+	return getLogThreshold() == LevelDebug
 }
 
 // LogThreshold will return the current log level
 func LogThreshold() int {
-	return logThreshold
+	return getLogThreshold()
+}
+
+// SetFormatter sets the formatter used for this LogLevel only, overriding
+// the package-wide default set via simplelog.SetFormatter.
+func (l *LogLevel) SetFormatter(f Formatter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.formatter = f
+}
+
+// ExitFunc is called with status code 1 whenever a message is logged at
+// LevelFatal (unless one of the *n "no-exit" variants is used). Tests and
+// applications that need to run deferred cleanup instead of exiting
+// immediately can override it, e.g. with a function that panics.
+var ExitFunc func(int) = os.Exit
+
+// write sends msg/fields to this LogLevel's backend, if one is set via
+// SetBackend (rendered by renderForBackend, without the formatter), or
+// otherwise renders them through this LogLevel's formatter (or the package
+// default) and writes the result to its destination. It holds l.mu only for the
+// duration of the write itself, so it's safe to call concurrently with
+// SetOutput/SetBackend/SetFormatter. If exit is true and this is the Fatal
+// level, ExitFunc(1) is called once the message has been flushed and l.mu
+// has been released, so an ExitFunc override that calls back into Sync,
+// Close or another Printf on this same level won't deadlock.
+func (l *LogLevel) write(msg string, fields map[string]interface{}, exit bool) {
+	l.mu.Lock()
+	if l.level >= getLogThreshold() {
+		if l.backend != nil {
+			l.backend.Write(l.level, renderForBackend(msg, fields))
+			if flusher, ok := l.backend.(interface{ Flush() error }); ok {
+				flusher.Flush()
+			}
+		} else {
+			f := l.formatter
+			if f == nil {
+				f = getDefaultFormatter()
+			}
+			entry := Entry{Time: time.Now(), Level: l.level, Message: msg, Fields: fields}
+			l.destination.Write(f.Format(entry))
+			if flusher, ok := l.destination.(interface{ Flush() error }); ok {
+				flusher.Flush()
+			}
+		}
+	}
+	isFatal := l.level == LevelFatal
+	l.mu.Unlock()
+
+	if isFatal && exit {
+		ExitFunc(1)
+	}
+}
+
+// SetOutput changes the writer this LogLevel writes to. Safe for concurrent
+// use with Printf/Println/Log. Clears any backend set via SetBackend.
+func (l *LogLevel) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.destination = w
+	l.backend = nil
+}
+
+// SetBackend routes this LogLevel's messages to b instead of its
+// destination. Pass nil to go back to writing to the destination.
+func (l *LogLevel) SetBackend(b Backend) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.backend = b
+}
+
+// Sync flushes this LogLevel's backend if one is set via SetBackend and it
+// implements a Sync() error method, or otherwise its destination, if that
+// implements Sync() error (as *os.File does). It's a no-op if neither does.
+func (l *LogLevel) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.backend != nil {
+		if s, ok := l.backend.(interface{ Sync() error }); ok {
+			return s.Sync()
+		}
+		return nil
+	}
+	if s, ok := l.destination.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close closes this LogLevel's backend if one is set via SetBackend and it
+// implements io.Closer, or otherwise its destination, if that implements
+// io.Closer. It's a no-op if neither does. Useful for shutting down
+// file- or connection-backed destinations on program shutdown.
+func (l *LogLevel) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.backend != nil {
+		if c, ok := l.backend.(io.Closer); ok {
+			return c.Close()
+		}
+		return nil
+	}
+	if c, ok := l.destination.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SetOutput changes the writer used by the given log level (one of
+// LevelDebug..LevelFatal). Will return ErrInvalidThreshold if level isn't
+// in the accepted range.
+func SetOutput(level int, w io.Writer) error {
+	l, err := logLevelFor(level)
+	if err != nil {
+		return err
+	}
+	l.SetOutput(w)
+	return nil
+}
+
+// SetAllOutputs changes the writer used by every log level, e.g. to point
+// Debug/Info/Warning/Error/Fatal all at the same io.MultiWriter.
+func SetAllOutputs(w io.Writer) {
+	for _, l := range allLevels {
+		l.SetOutput(w)
+	}
+}
+
+// Sync flushes every log level's destination. See (*LogLevel).Sync.
+func Sync() error {
+	for _, l := range allLevels {
+		if err := l.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes every log level's destination. See (*LogLevel).Close.
+func Close() error {
+	for _, l := range allLevels {
+		if err := l.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Log writes msg to this LogLevel along with the given attribute pairs,
+// rendered through the active Formatter (or, when a Backend is set, as
+// trailing "key=value" text; see Backend). Message will only get written if
+// current log level allows it (it won't write INFO messages if we're at ERROR).
+func (l *LogLevel) Log(msg string, fields map[string]interface{}) {
+	l.write(msg, fields, true)
 }
 
 // Printf will use the logger attached to this LogLevel to write a log message.
 // Message will only get written if current log level allows it (it won't write INFO messages if we're at ERROR)
 // When writing to the Fatal log level the program will automatically exit with status code 1
 func (l *LogLevel) Printf(format string, v ...interface{}) {
-	if l.level >= logThreshold {
-		l.logger.Printf(format, v...)
-	}
-	if l.level == LevelFatal {
-		os.Exit(1)
-	}
+	l.write(fmt.Sprintf(format, v...), nil, true)
 }
 
 // Println will use the logger attached to this LogLevel to write a log message.
 // Message will only get written if current log level allows it (it won't write INFO messages if we're at ERROR)
 // When writing to the Fatal log level the program will automatically exit with status code 1
 func (l *LogLevel) Println(v ...interface{}) {
-	if l.level >= logThreshold {
-		l.logger.Println(v...)
-	}
-	if l.level == LevelFatal {
-		os.Exit(1)
-	}
+	l.write(strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil, true)
+}
+
+// Printfn behaves like Printf but never exits, even when called on Fatal:
+// useful when the caller wants to log a fatal-severity event and then run
+// its own deferred cleanup or panic instead of calling os.Exit directly.
+func (l *LogLevel) Printfn(format string, v ...interface{}) {
+	l.write(fmt.Sprintf(format, v...), nil, false)
+}
+
+// Printlnn behaves like Println but never exits, even when called on Fatal.
+// See Printfn.
+func (l *LogLevel) Printlnn(v ...interface{}) {
+	l.write(strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil, false)
+}
+
+// Debugw logs msg at Debug level along with the given attribute pairs. See (*LogLevel).Log.
+func Debugw(msg string, fields map[string]interface{}) {
+	Debug.Log(msg, fields)
+}
+
+// Infow logs msg at Info level along with the given attribute pairs. See (*LogLevel).Log.
+func Infow(msg string, fields map[string]interface{}) {
+	Info.Log(msg, fields)
+}
+
+// Warningw logs msg at Warning level along with the given attribute pairs. See (*LogLevel).Log.
+func Warningw(msg string, fields map[string]interface{}) {
+	Warning.Log(msg, fields)
+}
+
+// Errorw logs msg at Error level along with the given attribute pairs. See (*LogLevel).Log.
+func Errorw(msg string, fields map[string]interface{}) {
+	Error.Log(msg, fields)
+}
+
+// Fatalw logs msg at Fatal level along with the given attribute pairs and then exits. See (*LogLevel).Log.
+func Fatalw(msg string, fields map[string]interface{}) {
+	Fatal.Log(msg, fields)
 }
@@ -0,0 +1,70 @@
+// Package syslog wires a simplelog.LogLevel to a syslog backend, dispatching
+// each message to the syslog severity matching its simplelog level.
+package syslog
+
+import (
+	"log/syslog"
+
+	simplelog "github.com/FranGM/simplelog"
+)
+
+// severityWriter is the subset of *syslog.Writer's methods Backend
+// dispatches to. It exists so tests can exercise the severity-mapping
+// logic in Backend.Write without dialing a real syslog daemon.
+type severityWriter interface {
+	Debug(m string) error
+	Info(m string) error
+	Warning(m string) error
+	Err(m string) error
+	Emerg(m string) error
+	Close() error
+}
+
+// Backend adapts a *syslog.Writer to the simplelog.Backend interface.
+type Backend struct {
+	w severityWriter
+}
+
+// Write implements simplelog.Backend, dispatching msg to the syslog
+// severity matching level: Debug->LOG_DEBUG, Info->LOG_INFO,
+// Warning->LOG_WARNING, Error->LOG_ERR, Fatal->LOG_EMERG.
+func (b *Backend) Write(level int, msg string) error {
+	switch level {
+	case simplelog.LevelDebug:
+		return b.w.Debug(msg)
+	case simplelog.LevelInfo:
+		return b.w.Info(msg)
+	case simplelog.LevelWarning:
+		return b.w.Warning(msg)
+	case simplelog.LevelError:
+		return b.w.Err(msg)
+	case simplelog.LevelFatal:
+		return b.w.Emerg(msg)
+	default:
+		return b.w.Info(msg)
+	}
+}
+
+// Close releases the underlying syslog connection.
+func (b *Backend) Close() error {
+	return b.w.Close()
+}
+
+// UseSyslog wires every simplelog LogLevel (Debug, Info, Warning, Error,
+// Fatal) to a syslog backend identified by tag, reached over network at
+// addr (e.g. UseSyslog("myapp", "udp", "localhost:514")). Passing an empty
+// network dials the local syslog daemon.
+func UseSyslog(tag, network, addr string) error {
+	w, err := syslog.Dial(network, addr, syslog.LOG_DEBUG, tag)
+	if err != nil {
+		return err
+	}
+
+	backend := &Backend{w: w}
+	simplelog.Debug.SetBackend(backend)
+	simplelog.Info.SetBackend(backend)
+	simplelog.Warning.SetBackend(backend)
+	simplelog.Error.SetBackend(backend)
+	simplelog.Fatal.SetBackend(backend)
+	return nil
+}
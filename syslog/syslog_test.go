@@ -0,0 +1,57 @@
+package syslog
+
+import (
+	"testing"
+
+	simplelog "github.com/FranGM/simplelog"
+)
+
+// fakeWriter records the severity method it was called through, so tests
+// can assert on Backend's level-to-severity mapping without a real
+// syslog daemon.
+type fakeWriter struct {
+	severity string
+	msg      string
+}
+
+func (f *fakeWriter) Debug(m string) error   { f.severity, f.msg = "debug", m; return nil }
+func (f *fakeWriter) Info(m string) error    { f.severity, f.msg = "info", m; return nil }
+func (f *fakeWriter) Warning(m string) error { f.severity, f.msg = "warning", m; return nil }
+func (f *fakeWriter) Err(m string) error     { f.severity, f.msg = "err", m; return nil }
+func (f *fakeWriter) Emerg(m string) error   { f.severity, f.msg = "emerg", m; return nil }
+func (f *fakeWriter) Close() error           { return nil }
+
+func TestBackendWriteDispatchesBySeverity(t *testing.T) {
+	cases := []struct {
+		level        int
+		wantSeverity string
+	}{
+		{simplelog.LevelDebug, "debug"},
+		{simplelog.LevelInfo, "info"},
+		{simplelog.LevelWarning, "warning"},
+		{simplelog.LevelError, "err"},
+		{simplelog.LevelFatal, "emerg"},
+	}
+
+	for _, c := range cases {
+		fw := &fakeWriter{}
+		b := &Backend{w: fw}
+		if err := b.Write(c.level, "hello"); err != nil {
+			t.Fatalf("Backend.Write(%d, ...) returned error: %v", c.level, err)
+		}
+		if fw.severity != c.wantSeverity {
+			t.Errorf("Backend.Write(%d, ...) dispatched to severity %q, want %q", c.level, fw.severity, c.wantSeverity)
+		}
+		if fw.msg != "hello" {
+			t.Errorf("Backend.Write(%d, ...) passed msg %q, want %q", c.level, fw.msg, "hello")
+		}
+	}
+}
+
+func TestBackendClose(t *testing.T) {
+	fw := &fakeWriter{}
+	b := &Backend{w: fw}
+	if err := b.Close(); err != nil {
+		t.Errorf("Backend.Close() = %v, want nil", err)
+	}
+}